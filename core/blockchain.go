@@ -0,0 +1,44 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockChain represents the canonical chain given a database with a genesis block. It is
+// trimmed here to the piece needed to wire live-tracer hooks into the reorg path; the rest of
+// the type (header chain, state cache, fork choice, block insertion, etc.) lives alongside this
+// in the full tree.
+type BlockChain struct {
+	// logger holds the live-tracer hooks configured for this chain, if any. It is populated
+	// from the node's vm.Config when the chain is constructed.
+	logger *tracing.Hooks
+}
+
+// reorg is called once (*BlockChain).writeBlockWithState has determined that the canonical
+// chain pointer is moving from oldChain to newChain (both ordered oldest-first), after the new
+// chain segment has already been validated and its state written. It notifies any configured
+// live-tracer hooks of the reorg so they can reconcile records they already emitted for the
+// blocks being removed.
+func (bc *BlockChain) reorg(oldChain, newChain []*types.Block) {
+	if bc.logger == nil || bc.logger.OnReorg == nil || len(oldChain) == 0 {
+		return
+	}
+	bc.logger.OnReorg(oldChain, newChain)
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// VMContext provides the context for the EVM executing a transaction, available to OnTxStart.
+type VMContext struct {
+	Coinbase    common.Address
+	BlockNumber *big.Int
+	Time        uint64
+	Random      *common.Hash
+	BaseFee     *big.Int
+}
+
+// BlockEvent is passed to OnBlockStart. Safe and Finalized may be nil if the chain has not yet
+// marked a block as such.
+type BlockEvent struct {
+	Block     *types.Block
+	TD        *big.Int
+	Finalized *types.Header
+	Safe      *types.Header
+}
+
+// OpContext provides the context for an EVM opcode as it executes, available to OnOpcode and
+// OnFault.
+type OpContext interface {
+	MemoryData() []byte
+	StackData() []uint256.Int
+	Caller() common.Address
+	Address() common.Address
+	CallValue() *uint256.Int
+	CallInput() []byte
+}
+
+// Hooks is a collection of callbacks a live tracer, such as eth/tracers/live's txlogger, can
+// implement to observe block and transaction execution. Every field is optional; a nil hook is
+// simply not called.
+type Hooks struct {
+	// Block events
+	OnBlockStart func(event BlockEvent)
+	OnBlockEnd   func(err error)
+	// OnReorg is called once a chain reorg has been applied, with oldChain holding the blocks
+	// that are no longer canonical and newChain holding the blocks that replaced them, both
+	// ordered oldest-first.
+	OnReorg func(oldChain, newChain []*types.Block)
+
+	// Transaction events
+	OnTxStart func(vm *VMContext, tx *types.Transaction, from common.Address)
+	OnTxEnd   func(receipt *types.Receipt, err error)
+
+	// EVM events
+	OnOpcode func(pc uint64, op byte, gas, cost uint64, scope OpContext, rData []byte, depth int, err error)
+	OnFault  func(pc uint64, op byte, gas, cost uint64, scope OpContext, depth int, err error)
+}
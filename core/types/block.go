@@ -0,0 +1,73 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Header represents a block header, trimmed here to the fields the rollup L1 cost and
+// live-tracer code needs; the full header (receipts root, difficulty, extra data, withdrawals
+// root, etc.) lives alongside this in the full tree.
+type Header struct {
+	ParentHash common.Hash
+	Number     *big.Int
+	Time       uint64
+	BaseFee    *big.Int
+
+	hash atomic.Pointer[common.Hash]
+}
+
+// Hash returns the header's hash, computing and caching it on first use.
+func (h *Header) Hash() common.Hash {
+	if v := h.hash.Load(); v != nil {
+		return *v
+	}
+	v := rlpHash(h)
+	h.hash.Store(&v)
+	return v
+}
+
+// Block represents an Ethereum block, trimmed here to what the live-tracer hooks need to
+// identify a block and walk its transactions.
+type Block struct {
+	header       *Header
+	transactions []*Transaction
+}
+
+// NewBlockWithHeader creates a block with the given header and no transactions. Transactions
+// can be added with WithBody.
+func NewBlockWithHeader(header *Header) *Block {
+	return &Block{header: header}
+}
+
+// WithBody returns a copy of the block with the given transactions attached.
+func (b *Block) WithBody(transactions []*Transaction) *Block {
+	out := *b
+	out.transactions = transactions
+	return &out
+}
+
+func (b *Block) Header() *Header              { return b.header }
+func (b *Block) Hash() common.Hash            { return b.header.Hash() }
+func (b *Block) NumberU64() uint64            { return b.header.Number.Uint64() }
+func (b *Block) Time() uint64                 { return b.header.Time }
+func (b *Block) ParentHash() common.Hash      { return b.header.ParentHash }
+func (b *Block) Transactions() []*Transaction { return b.transactions }
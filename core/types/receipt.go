@@ -0,0 +1,52 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Receipt represents the results of a transaction, trimmed here to the fields the rollup L1
+// cost code reads and writes; the full receipt (logs, bloom filter, RLP encoding, etc.) lives
+// alongside this in the full tree.
+type Receipt struct {
+	// Consensus fields
+	Type              byte
+	PostState         []byte
+	Status            uint64
+	CumulativeGasUsed uint64
+
+	// Implementation fields
+	TxHash           common.Hash
+	ContractAddress  common.Address
+	GasUsed          uint64
+	BlockHash        common.Hash
+	BlockNumber      *big.Int
+	TransactionIndex uint
+
+	// Rollup L1 data fee fields.
+	L1GasUsed           *big.Int
+	L1Fee               *big.Int
+	L1BaseFeeScalar     *big.Int
+	L1BlobBaseFee       *big.Int
+	L1BlobBaseFeeScalar *big.Int
+	// L1FeeType records which fee-pricing scheme the above fields were computed under; see
+	// L1CostData.L1FeeType for the legacy/dynamic-fee/blob distinction.
+	L1FeeType L1FeeType
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+
+	"github.com/andybalholm/brotli"
+)
+
+// CompressionAlgo selects which CompressionEstimator newL1CostFunc uses to estimate a tx's
+// compressed size for the L1 data fee, via params.ChainConfig's L1CompressionAlgo field.
+type CompressionAlgo uint8
+
+const (
+	// FastLZAlgo is the default, matching the batcher's historical FastLZ-based estimate.
+	FastLZAlgo CompressionAlgo = iota
+	// BrotliAlgo estimates using Brotli at quality 0, for chains whose batcher compresses
+	// with Brotli instead of FastLZ.
+	BrotliAlgo
+)
+
+// CompressionEstimator estimates the size, in bytes, that data would compress to if posted to
+// L1, for use in the L1 data fee calculation.
+type CompressionEstimator interface {
+	// CompressedLen returns the estimated compressed length of data.
+	CompressedLen(data []byte) uint32
+}
+
+// FastLZCompressionEstimator is the default CompressionEstimator. It allocates a fresh hash
+// table on every call; PooledFastLZCompressionEstimator avoids that cost for callers that
+// process many transactions, such as block processing.
+type FastLZCompressionEstimator struct{}
+
+func (FastLZCompressionEstimator) CompressedLen(data []byte) uint32 {
+	return FlzCompressLen(data)
+}
+
+// pooledFastLZCompressionEstimator is a CompressionEstimator that reuses FastLZ's 8192-entry
+// hash table across calls via a sync.Pool instead of allocating a new one every time.
+type pooledFastLZCompressionEstimator struct {
+	pool sync.Pool
+}
+
+// PooledFastLZCompressionEstimator is a process-wide CompressionEstimator for callers, such as
+// block processing and tx pool admission, that estimate the compressed size of many
+// transactions and want to amortize the hash table allocation across calls.
+var PooledFastLZCompressionEstimator CompressionEstimator = &pooledFastLZCompressionEstimator{
+	pool: sync.Pool{
+		New: func() any {
+			ht := make([]uint32, flzHashTableSize)
+			return &ht
+		},
+	},
+}
+
+func (e *pooledFastLZCompressionEstimator) CompressedLen(data []byte) uint32 {
+	htp := e.pool.Get().(*[]uint32)
+	defer e.pool.Put(htp)
+	ht := *htp
+	for i := range ht {
+		ht[i] = 0
+	}
+	return flzCompressLen(data, ht)
+}
+
+// BrotliQ0CompressionEstimator estimates compressed length using Brotli at quality 0, the
+// fastest (and least dense) Brotli setting, matching the batcher's Brotli-based estimate on
+// chains that configure L1CompressionAlgo to BrotliAlgo.
+type BrotliQ0CompressionEstimator struct{}
+
+func (BrotliQ0CompressionEstimator) CompressedLen(data []byte) uint32 {
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, 0)
+	// Brotli at quality 0 on in-memory data never errors; a write failure here would mean
+	// bytes.Buffer itself returned an error, which it never does.
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return uint32(buf.Len())
+}
+
+// compressedSizeCache caches a transaction's compressed-size estimate, computed lazily on the
+// first call to CompressedLen and invalidated whenever the underlying transaction is
+// re-encoded. types.Transaction embeds one so that block processing, tx pool admission, and RPC
+// don't each recompute the same estimate for a given tx.
+type compressedSizeCache struct {
+	// size stores the cached value plus one, so that zero means "not yet computed" and a
+	// genuine zero-length estimate is still representable.
+	size atomic.Uint32
+}
+
+// CompressedLen returns the cached compressed-size estimate for data, computing and storing it
+// via estimator on first use.
+func (c *compressedSizeCache) CompressedLen(data []byte, estimator CompressionEstimator) uint32 {
+	if v := c.size.Load(); v != 0 {
+		return v - 1
+	}
+	v := estimator.CompressedLen(data)
+	c.size.Store(v + 1)
+	return v
+}
+
+// invalidate clears the cached estimate. Transaction calls this whenever it re-encodes itself,
+// since the cached estimate is only valid for the byte-for-byte calldata it was computed from.
+func (c *compressedSizeCache) invalidate() {
+	c.size.Store(0)
+}
+
+// compressionEstimatorForAlgo returns the CompressionEstimator to use for algo. It always
+// returns the pooled FastLZ estimator for FastLZAlgo, since that is the hot path used for every
+// tx in a block.
+func compressionEstimatorForAlgo(algo CompressionAlgo) CompressionEstimator {
+	switch algo {
+	case BrotliAlgo:
+		return BrotliQ0CompressionEstimator{}
+	default:
+		return PooledFastLZCompressionEstimator
+	}
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// mainnetSizedCalldata returns pseudo-random calldata roughly the size of a typical L2
+// transaction's calldata once posted to L1, for use in the benchmarks below.
+func mainnetSizedCalldata() []byte {
+	r := rand.New(rand.NewSource(1))
+	data := make([]byte, 2000)
+	r.Read(data)
+	// Calldata is rarely fully random; zero out a chunk to mimic padding/ABI-encoded zeroes.
+	for i := 0; i < len(data)/4; i++ {
+		data[i] = 0
+	}
+	return data
+}
+
+func BenchmarkFlzCompressLen(b *testing.B) {
+	data := mainnetSizedCalldata()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FlzCompressLen(data)
+	}
+}
+
+func BenchmarkPooledFastLZCompressionEstimator(b *testing.B) {
+	data := mainnetSizedCalldata()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		PooledFastLZCompressionEstimator.CompressedLen(data)
+	}
+}
+
+func BenchmarkBrotliQ0CompressionEstimator(b *testing.B) {
+	data := mainnetSizedCalldata()
+	estimator := BrotliQ0CompressionEstimator{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		estimator.CompressedLen(data)
+	}
+}
+
+func TestCompressedSizeCache(t *testing.T) {
+	data := mainnetSizedCalldata()
+	estimator := FastLZCompressionEstimator{}
+	want := estimator.CompressedLen(data)
+
+	var cache compressedSizeCache
+	if got := cache.CompressedLen(data, estimator); got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+	// Second call should hit the cache; pass a nil estimator to prove it isn't invoked again.
+	if got := cache.CompressedLen(data, nil); got != want {
+		t.Fatalf("cached call: got %d, want %d", got, want)
+	}
+
+	cache.invalidate()
+	if got := cache.CompressedLen(data, estimator); got != want {
+		t.Fatalf("after invalidate: got %d, want %d", got, want)
+	}
+}
+
+// TestTransactionL1CostDataCache checks that Transaction wires its costCache into L1CostData, so
+// that repeated L1CostData calls against the same transaction only estimate its compressed size
+// once, and that re-encoding the transaction invalidates the cached estimate.
+func TestTransactionL1CostDataCache(t *testing.T) {
+	data := mainnetSizedCalldata()
+	tx := NewTransaction(0, common.HexToAddress("095e7baea6a6c7c4c2dfeb977efac326af552d87"), big.NewInt(0), 0, big.NewInt(0), data)
+	estimator := FastLZCompressionEstimator{}
+	want := estimator.CompressedLen(data)
+
+	if got := tx.L1CostData().compressedLen(estimator); got != want {
+		t.Fatalf("got %d, want %d", got, want)
+	}
+	// Second call should hit tx's cache; pass a nil estimator to prove it isn't invoked again.
+	if got := tx.L1CostData().compressedLen(nil); got != want {
+		t.Fatalf("cached call: got %d, want %d", got, want)
+	}
+
+	newData := append([]byte{0xff, 0xff, 0xff, 0xff}, data...)
+	tx.setData(newData)
+	wantNew := estimator.CompressedLen(newData)
+	if got := tx.L1CostData().compressedLen(estimator); got != wantNew {
+		t.Fatalf("after setData: got %d, want %d (re-computed against new data)", got, wantNew)
+	}
+}
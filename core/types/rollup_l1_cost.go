@@ -17,6 +17,7 @@
 package types
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -25,6 +26,21 @@ import (
 
 type L1CostData struct {
 	data []byte
+	// isBlobTx records how the batch this data belongs to was posted to L1: true for an
+	// EIP-4844 blob, false for ordinary calldata. It's a property of the batch, not of any one
+	// L2 transaction, so it's only ever set explicitly via NewBlobL1CostData by a caller with
+	// that batch-level context, never derived from a transaction's own envelope type.
+	isBlobTx  bool
+	txType    byte
+	gasTipCap *big.Int
+	gasFeeCap *big.Int
+
+	// cache is the transaction's compressedSizeCache, set by Transaction.L1CostData so that
+	// compressedLen serves repeated calls (block processing, tx pool, RPC) from one computation
+	// per transaction instead of re-running the estimator every time. It is nil for an
+	// L1CostData built directly from calldata via NewL1CostData/NewBlobL1CostData, which have no
+	// owning transaction to cache against.
+	cache *compressedSizeCache
 }
 
 func NewL1CostData(data []byte) (out L1CostData) {
@@ -33,6 +49,90 @@ func NewL1CostData(data []byte) (out L1CostData) {
 	}
 }
 
+// NewBlobL1CostData is like NewL1CostData, but marks the data as having been posted to L1
+// via an EIP-4844 blob rather than ordinary calldata. Whether a tx's data went out as a blob is
+// a property of how the batcher posted the batch it belongs to, not of the tx itself, so callers
+// with that batch-level context (e.g. block processing, once it tracks which batch a tx landed
+// in) construct an L1CostData this way instead of going through NewL1CostDataFromTx.
+func NewBlobL1CostData(data []byte) (out L1CostData) {
+	return L1CostData{
+		data:     data,
+		isBlobTx: true,
+	}
+}
+
+// NewL1CostDataFromTx builds an L1CostData for tx, carrying its type and, for dynamic-fee and
+// blob transactions, its tip and fee cap, so that RPC helpers can report an L1FeeType alongside
+// the estimated fee without re-inspecting the transaction. It always reports isBlobTx false:
+// the L2 tx's own envelope type (BlobTxType covers EIP-4844 blob-carrying txs on L2) says
+// nothing about how the batcher posted the batch this tx landed in, so it is not a valid source
+// for that flag; see NewBlobL1CostData.
+func NewL1CostDataFromTx(tx *Transaction) (out L1CostData) {
+	return L1CostData{
+		data:      tx.Data(),
+		txType:    tx.Type(),
+		gasTipCap: tx.GasTipCap(),
+		gasFeeCap: tx.GasFeeCap(),
+	}
+}
+
+// IsBlobTx reports whether the data was posted to L1 via an EIP-4844 blob.
+func (c L1CostData) IsBlobTx() bool {
+	return c.isBlobTx
+}
+
+// compressedLen returns the estimated compressed size of c's data, consulting c.cache first if
+// one is set so that repeated calls against the same transaction only run estimator once.
+func (c L1CostData) compressedLen(estimator CompressionEstimator) uint32 {
+	if c.cache != nil {
+		return c.cache.CompressedLen(c.data, estimator)
+	}
+	return estimator.CompressedLen(c.data)
+}
+
+// EffectiveGasPrice returns the per-gas price the sender would actually pay for this
+// transaction's L2 execution given baseFee, so that RPC callers can combine it with the L1 data
+// fee L1CostEstimate returns to quote a total fee. It returns nil if c was built directly from
+// calldata (via NewL1CostData/NewBlobL1CostData) rather than from a transaction, since no price
+// is known in that case. For legacy and access-list transactions the result is just the gas
+// price; for dynamic-fee and blob transactions it is min(baseFee+tip, feeCap), matching the
+// EIP-1559 effective gas price rule.
+func (c L1CostData) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if c.gasTipCap == nil || c.gasFeeCap == nil {
+		return nil
+	}
+	price := new(big.Int).Add(baseFee, c.gasTipCap)
+	if price.Cmp(c.gasFeeCap) > 0 {
+		price.Set(c.gasFeeCap)
+	}
+	return price
+}
+
+// L1FeeType identifies which fee-pricing scheme a receipt's L1 fee fields were computed under,
+// so indexers can distinguish legacy calldata-priced, EIP-1559-aware, and blob-priced
+// transactions without re-deriving the tx type.
+type L1FeeType byte
+
+const (
+	L1FeeTypeLegacy L1FeeType = iota
+	L1FeeTypeDynamicFee
+	L1FeeTypeBlob
+)
+
+// L1FeeType reports which L1FeeType a receipt for this tx should record.
+func (c L1CostData) L1FeeType() L1FeeType {
+	switch c.txType {
+	case BlobTxType:
+		return L1FeeTypeBlob
+	case DynamicFeeTxType:
+		return L1FeeTypeDynamicFee
+	default:
+		// AccessListTxType is priced like LegacyTxType: both carry a single gasPrice rather
+		// than an EIP-1559 tip/fee cap, so neither is "dynamic-fee".
+		return L1FeeTypeLegacy
+	}
+}
+
 type StateGetter interface {
 	GetState(common.Address, common.Hash) common.Hash
 }
@@ -48,36 +148,91 @@ type l1CostFunc func(tx L1CostData) (fee, gasUsed *big.Int)
 var (
 	L1BaseFeeSlot = common.BigToHash(big.NewInt(1))
 	OverheadSlot  = common.BigToHash(big.NewInt(5))
-	ScalarSlot    = common.BigToHash(big.NewInt(6))
+	// ScalarSlot is the pre-Ecotone calldata scalar, read by the zeroes/ones-based cost path.
+	ScalarSlot = common.BigToHash(big.NewInt(6))
+	// L1BaseFeeScalarSlot is the Ecotone calldata (base fee) scalar. It is a distinct slot from
+	// the legacy ScalarSlot: Ecotone's L1Block predeploy packs the base-fee and blob-base-fee
+	// scalars into their own storage slots rather than reusing the pre-Ecotone scalar slot, so
+	// l1CostFuncFromOracle reads slot 7, not slot 6, once Ecotone is active.
+	L1BaseFeeScalarSlot     = common.BigToHash(big.NewInt(7))
+	L1BlobBaseFeeSlot       = common.BigToHash(big.NewInt(8))
+	L1BlobBaseFeeScalarSlot = common.BigToHash(big.NewInt(9))
 )
 
 var L1BlockAddr = common.HexToAddress("0x4200000000000000000000000000000000000015")
 
+// ecotoneDivisor is the fixed point divisor used by the Ecotone L1 fee formula, see
+// l1CostEcotone for details.
+var ecotoneDivisor = big.NewInt(1_000_000 * 16)
+
 // NewL1CostFunc returns a function used for calculating L1 fee cost.  This depends on the oracles
 // because gas costs can change over time, and depends on blockTime since the specific function
 // used to compute the fee can differ between hardforks.
 func NewL1CostFunc(config *params.ChainConfig, statedb StateGetter, blockTime uint64) L1CostFunc {
-	l1BaseFee := statedb.GetState(L1BlockAddr, L1BaseFeeSlot).Big()
-	overhead := statedb.GetState(L1BlockAddr, OverheadSlot).Big()
-	scalar := statedb.GetState(L1BlockAddr, ScalarSlot).Big()
-	f := newL1CostFunc(config, l1BaseFee, overhead, scalar, blockTime)
+	f := l1CostFuncFromOracle(config, statedb, blockTime)
 	return func(l1CostData L1CostData) *big.Int {
 		fee, _ := f(l1CostData)
 		return fee
 	}
 }
 
-func newL1CostFunc(config *params.ChainConfig, l1BaseFee, overhead, scalar *big.Int, blockTime uint64) l1CostFunc {
+// l1CostFuncFromOracle reads the L1 oracle values out of statedb and builds an l1CostFunc from
+// them. It is shared by NewL1CostFunc and L1CostEstimate so both read the oracle the same way.
+func l1CostFuncFromOracle(config *params.ChainConfig, statedb StateGetter, blockTime uint64) l1CostFunc {
+	l1BaseFee := statedb.GetState(L1BlockAddr, L1BaseFeeSlot).Big()
+	overhead := statedb.GetState(L1BlockAddr, OverheadSlot).Big()
+	var scalar, blobBaseFee, blobBaseFeeScalar *big.Int
+	if config.IsEcotone(blockTime) {
+		scalar = statedb.GetState(L1BlockAddr, L1BaseFeeScalarSlot).Big()
+		blobBaseFee = statedb.GetState(L1BlockAddr, L1BlobBaseFeeSlot).Big()
+		blobBaseFeeScalar = statedb.GetState(L1BlockAddr, L1BlobBaseFeeScalarSlot).Big()
+	} else {
+		scalar = statedb.GetState(L1BlockAddr, ScalarSlot).Big()
+	}
+	return newL1CostFunc(config, l1BaseFee, overhead, scalar, blobBaseFee, blobBaseFeeScalar, blockTime)
+}
+
+// L1CostEstimate estimates the L1 data fee tx would be charged if included in a block built on
+// top of header, along with the L1 gasUsed that fee is based on. Unlike L1CostFunc, which is
+// built once per block from data already read out of the state, L1CostEstimate re-reads the L1
+// oracle from statedb itself so that RPC helpers such as eth_estimateGas and eth_gasPrice can
+// get a one-off estimate for a transaction that has not yet been (and may never be) included.
+//
+// The oracle values live in state, not in tx or header, so callers (e.g. internal/ethapi) must
+// pass the statedb to read them from and the ChainConfig needed to pick the right hardfork's
+// pricing formula; a two-argument (tx, header) helper can't get at either without one.
+func L1CostEstimate(config *params.ChainConfig, statedb StateGetter, tx *Transaction, header *Header) (fee, gasUsed *big.Int, err error) {
+	if tx == nil || header == nil {
+		return nil, nil, errors.New("types: L1CostEstimate requires a transaction and a header")
+	}
+	if config.Optimism == nil {
+		return nil, nil, nil
+	}
+	f := l1CostFuncFromOracle(config, statedb, header.Time)
+	fee, gasUsed = f(NewL1CostDataFromTx(tx))
+	return fee, gasUsed, nil
+}
+
+func newL1CostFunc(config *params.ChainConfig, l1BaseFee, overhead, scalar, blobBaseFee, blobBaseFeeScalar *big.Int, blockTime uint64) l1CostFunc {
 	isRegolith := config.IsRegolith(blockTime)
 	isEclipse := config.IsEclipse(blockTime)
+	isEcotone := config.IsEcotone(blockTime)
+	estimator := compressionEstimatorForAlgo(config.L1CompressionAlgo)
 	return func(l1CostData L1CostData) (fee, gasUsed *big.Int) {
 		if config.Optimism == nil {
 			return nil, nil
 		}
 
+		// Ecotone prices the tx's compressed size against both the L1 base fee and the L1
+		// blob base fee, weighted by their respective scalars, so that the fee stays correct
+		// whichever way the batcher happened to post this tx's data to L1.
+		if isEcotone {
+			return l1CostEcotone(l1CostData, l1BaseFee, blobBaseFee, scalar, blobBaseFeeScalar, estimator)
+		}
+
 		gas := uint64(0)
 		if isEclipse {
-			gas = uint64(FlzCompressLen(l1CostData.data)) * params.TxDataNonZeroGasEIP2028
+			gas = uint64(l1CostData.compressedLen(estimator)) * params.TxDataNonZeroGasEIP2028
 		} else {
 			zeroes := uint64(0)
 			ones := uint64(0)
@@ -104,12 +259,66 @@ func newL1CostFunc(config *params.ChainConfig, l1BaseFee, overhead, scalar *big.
 	}
 }
 
+// l1CostEcotone implements the Ecotone L1 fee formula:
+//
+//	l1Cost = tx_compressed_size * (16*l1BaseFee*baseFeeScalar + l1BlobBaseFee*blobBaseFeeScalar) / 16e6
+//
+// Both weighted terms are always summed: it is the scalars, not a per-tx branch, that decide
+// which term dominates. The L1 oracle sets baseFeeScalar and blobBaseFeeScalar so that whichever
+// medium the batcher isn't currently using prices to ~0, which is why l1CostEcotone itself never
+// needs to know, per tx, which medium carried this tx's data. tx_compressed_size is derived from
+// estimator (via l1CostData.compressedLen, so a cached estimate is reused if one is available)
+// rather than the zero/non-zero byte count used pre-Ecotone. gasUsed is tx_compressed_size
+// itself, in bytes: unlike the pre-Ecotone l1GasUsed it is not scaled to L1 gas and does not
+// include the overhead.
+func l1CostEcotone(l1CostData L1CostData, l1BaseFee, blobBaseFee, baseFeeScalar, blobBaseFeeScalar *big.Int, estimator CompressionEstimator) (fee, gasUsed *big.Int) {
+	compressedSize := new(big.Int).SetUint64(uint64(l1CostData.compressedLen(estimator)))
+
+	calldataTerm := new(big.Int).Mul(big.NewInt(16), l1BaseFee)
+	calldataTerm.Mul(calldataTerm, baseFeeScalar)
+
+	blobTerm := new(big.Int).Mul(blobBaseFee, blobBaseFeeScalar)
+
+	feeScaled := new(big.Int).Add(calldataTerm, blobTerm)
+	l1Cost := new(big.Int).Mul(compressedSize, feeScaled)
+	l1Cost.Div(l1Cost, ecotoneDivisor)
+	return l1Cost, compressedSize
+}
+
+// PopulateL1Fields fills in receipt's rollup L1 data fee fields from l1CostData and the
+// breakdown l1CostFuncFromOracle used to compute l1Fee, so that receipts recorded at
+// block-processing time carry both the oracle values the fee was priced against and the
+// L1FeeType that pricing scheme corresponds to. It is a no-op if receipt is nil.
+func PopulateL1Fields(receipt *Receipt, l1CostData L1CostData, l1Fee, l1GasUsed, l1BaseFeeScalar, l1BlobBaseFee, l1BlobBaseFeeScalar *big.Int) {
+	if receipt == nil {
+		return
+	}
+	receipt.L1Fee = l1Fee
+	receipt.L1GasUsed = l1GasUsed
+	receipt.L1BaseFeeScalar = l1BaseFeeScalar
+	receipt.L1BlobBaseFee = l1BlobBaseFee
+	receipt.L1BlobBaseFeeScalar = l1BlobBaseFeeScalar
+	receipt.L1FeeType = l1CostData.L1FeeType()
+}
+
+// flzHashTableSize is the size of the hash table FlzCompressLen allocates on every call, and
+// that pooledFastLZCompressionEstimator instead reuses across calls via a sync.Pool.
+const flzHashTableSize = 8192
+
 // FlzCompressLen returns the length of the data after compression through FastLZ, based on
 // https://github.com/Vectorized/solady/blob/5315d937d79b335c668896d7533ac603adac5315/js/solady.js
 func FlzCompressLen(ib []byte) uint32 {
+	return flzCompressLen(ib, make([]uint32, flzHashTableSize))
+}
+
+// flzCompressLen is the core of FlzCompressLen, taking the hash table as a parameter so callers
+// that process many inputs (e.g. pooledFastLZCompressionEstimator) can reuse it across calls
+// instead of allocating a fresh one every time. ht must have length flzHashTableSize and its
+// contents are treated as scratch space: the caller is responsible for zeroing it beforehand if
+// reusing a table that may contain stale entries.
+func flzCompressLen(ib []byte, ht []uint32) uint32 {
 	n := uint32(0)
 	b := uint32(len(ib)) - 4
-	ht := make([]uint32, 8192)
 	a := uint32(0)
 	i := uint32(2)
 	d := uint32(0)
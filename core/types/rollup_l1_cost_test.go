@@ -20,10 +20,10 @@ func TestRollupGasData(t *testing.T) {
 		overhead := big.NewInt(1)
 		scalar := big.NewInt(1_000_000)
 
-		costFunc0 := newL1CostFunc(cfg, basefee, overhead, scalar, 0)
-		costFunc1 := newL1CostFunc(cfg, basefee, overhead, scalar, 1)
+		costFunc0 := newL1CostFunc(cfg, basefee, overhead, scalar, nil, nil, 0)
+		costFunc1 := newL1CostFunc(cfg, basefee, overhead, scalar, nil, nil, 1)
 
-		emptyTx = NewTransaction(
+		emptyTx := NewTransaction(
 			0,
 			common.HexToAddress("095e7baea6a6c7c4c2dfeb977efac326af552d87"),
 			big.NewInt(0), 0, big.NewInt(0),
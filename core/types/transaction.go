@@ -0,0 +1,131 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Transaction type values. These match the EIP-2718 envelope type byte.
+const (
+	LegacyTxType = iota
+	AccessListTxType
+	DynamicFeeTxType
+	BlobTxType
+)
+
+// Transaction represents an Ethereum transaction, trimmed here to the fields the rollup L1 cost
+// code and its callers read; the full transaction (signature, access list, blob sidecar, RLP
+// encoding, etc.) lives alongside this in the full tree.
+type Transaction struct {
+	txType    byte
+	nonce     uint64
+	gasPrice  *big.Int // legacy/access-list gas price
+	gasTipCap *big.Int // dynamic-fee/blob tip cap
+	gasFeeCap *big.Int // dynamic-fee/blob fee cap
+	gas       uint64
+	to        *common.Address
+	value     *big.Int
+	data      []byte
+
+	hash atomic.Pointer[common.Hash]
+
+	// costCache caches this transaction's compressed-size estimate across repeated L1CostData()
+	// calls from block processing, tx pool admission, and RPC, so they don't each recompute the
+	// same FastLZ/Brotli pass over the same calldata. It's invalidated by setData, which is
+	// called whenever the transaction's wire encoding (and therefore its calldata) is replaced.
+	costCache compressedSizeCache
+}
+
+// NewTransaction creates an unsigned legacy transaction.
+func NewTransaction(nonce uint64, to common.Address, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte) *Transaction {
+	return &Transaction{
+		txType:   LegacyTxType,
+		nonce:    nonce,
+		to:       &to,
+		value:    amount,
+		gas:      gasLimit,
+		gasPrice: gasPrice,
+		data:     common.CopyBytes(data),
+	}
+}
+
+func (tx *Transaction) Data() []byte        { return tx.data }
+func (tx *Transaction) Type() byte          { return tx.txType }
+func (tx *Transaction) Nonce() uint64       { return tx.nonce }
+func (tx *Transaction) To() *common.Address { return tx.to }
+func (tx *Transaction) Value() *big.Int     { return tx.value }
+func (tx *Transaction) Gas() uint64         { return tx.gas }
+
+// GasPrice returns the gas price for legacy and access-list transactions, or the fee cap for
+// dynamic-fee and blob transactions, matching what the sender pays per unit of gas in the
+// worst case.
+func (tx *Transaction) GasPrice() *big.Int {
+	if tx.gasPrice != nil {
+		return new(big.Int).Set(tx.gasPrice)
+	}
+	return new(big.Int).Set(tx.gasFeeCap)
+}
+
+// GasTipCap returns the tip cap for dynamic-fee and blob transactions, or the gas price for
+// legacy and access-list transactions, for which tip cap and gas price are the same thing.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.gasTipCap != nil {
+		return new(big.Int).Set(tx.gasTipCap)
+	}
+	return new(big.Int).Set(tx.gasPrice)
+}
+
+// GasFeeCap returns the fee cap for dynamic-fee and blob transactions, or the gas price for
+// legacy and access-list transactions, for which fee cap and gas price are the same thing.
+func (tx *Transaction) GasFeeCap() *big.Int {
+	if tx.gasFeeCap != nil {
+		return new(big.Int).Set(tx.gasFeeCap)
+	}
+	return new(big.Int).Set(tx.gasPrice)
+}
+
+// Hash returns the transaction hash, computing and caching it on first use.
+func (tx *Transaction) Hash() common.Hash {
+	if h := tx.hash.Load(); h != nil {
+		return *h
+	}
+	h := rlpHash(tx)
+	tx.hash.Store(&h)
+	return h
+}
+
+// setData replaces tx's calldata and invalidates any state derived from the old calldata: the
+// cached hash and the cached compressed-size estimate used by L1CostData. It's called whenever
+// tx is decoded from (or re-decoded over) its wire encoding.
+func (tx *Transaction) setData(data []byte) {
+	tx.data = common.CopyBytes(data)
+	tx.hash.Store(nil)
+	tx.costCache.invalidate()
+}
+
+// L1CostData returns the L1CostData used to compute this transaction's L1 data fee. The
+// compressed-size estimate it carries is served from tx's costCache, so repeated calls across
+// block processing, the tx pool, and RPC share one computation per transaction.
+func (tx *Transaction) L1CostData() L1CostData {
+	d := NewL1CostDataFromTx(tx)
+	d.cache = &tx.costCache
+	return d
+}
@@ -3,6 +3,9 @@ package live
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -18,11 +21,72 @@ func init() {
 }
 
 type txloggerConfig struct {
-	MaxAge int `json:"maxAge"` // Any transactions older than MaxAge are skipped (if 0, all transactions are logged)
+	MaxAge          int    `json:"maxAge"`          // Any transactions older than MaxAge are skipped (if 0, all transactions are logged)
+	OpcodeHistogram bool   `json:"opcodeHistogram"` // Accumulate a per-opcode gas-cost histogram and emit it with the tx's record once its block is flushed
+	TopSlots        int    `json:"topSlots"`        // Number of most frequently touched storage slots to report per tx (0 disables)
+	Output          string `json:"output"`          // File path to write the structured report to; "" or "-" writes to stderr
+	Confirmations   int    `json:"confirmations"`   // Blocks are flushed once this many confirmations deep; 0 waits for SafeBlock instead, or uses defaultConfirmations if the chain reports no SafeBlock
+}
+
+// flushedHistory bounds how many already-flushed blocks txlogger keeps around so that a reorg
+// reaching deeper than the configured confirmation depth can still be compensated for.
+const flushedHistory = 64
+
+// defaultConfirmations is the confirmation depth txlogger falls back to when Confirmations isn't
+// configured (0, the default) and the chain never reports a SafeBlock either. Without this
+// fallback that combination means every pending block looks unconfirmed forever, so buffered
+// records would accumulate without bound and nothing would ever be emitted.
+const defaultConfirmations = 64
+
+// txRecord holds everything txlogger knows about a single transaction, computed at OnTxEnd and
+// buffered until its containing block is confirmed.
+type txRecord struct {
+	tx         *types.Transaction
+	from       common.Address
+	receipt    *types.Receipt
+	duration   time.Duration
+	reads      uint64
+	writes     uint64
+	calls      uint64
+	logs       uint64
+	creates    uint64
+	faults     uint64
+	opcodeGas  map[vm.OpCode]uint64
+	slotCounts map[slotAccess]uint64
+	execErr    error
+}
+
+// blockBuffer holds the buffered txRecords for a single block, pending confirmation.
+type blockBuffer struct {
+	number  uint64
+	hash    common.Hash
+	records []*txRecord
+}
+
+// slotAccess identifies a single contract storage slot touched by a SLOAD or SSTORE.
+type slotAccess struct {
+	addr common.Address
+	slot common.Hash
+}
+
+// txReport is the structured JSON blob emitted for a transaction when OpcodeHistogram or
+// TopSlots is configured.
+type txReport struct {
+	TxHash          common.Hash       `json:"txHash"`
+	OpcodeHistogram map[string]uint64 `json:"opcodeHistogram,omitempty"`
+	HotSlots        []hotSlot         `json:"hotSlots,omitempty"`
+	Reverted        bool              `json:"reverted,omitempty"`
+}
+
+type hotSlot struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+	Count   uint64         `json:"count"`
 }
 
 type txlogger struct {
 	config  txloggerConfig
+	out     io.Writer
 	tx      *types.Transaction
 	from    common.Address
 	start   time.Time
@@ -32,6 +96,15 @@ type txlogger struct {
 	logs    uint64
 	creates uint64
 	faults  uint64
+
+	opcodeGas  map[vm.OpCode]uint64
+	slotCounts map[slotAccess]uint64
+
+	current *blockBuffer   // block currently being traced, not yet ended
+	pending []*blockBuffer // ended blocks, oldest first, awaiting confirmation
+	flushed []*blockBuffer // most recent flushedHistory blocks already emitted, for OnReorg
+
+	warnedNoSafeBlock bool // set once OnBlockStart has logged about falling back to defaultConfirmations
 }
 
 func newTxLoggerTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
@@ -42,14 +115,27 @@ func newTxLoggerTracer(cfg json.RawMessage) (*tracing.Hooks, error) {
 		}
 	}
 
+	out := io.Writer(os.Stderr)
+	if config.Output != "" && config.Output != "-" {
+		f, err := os.OpenFile(config.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open output file: %v", err)
+		}
+		out = f
+	}
+
 	t := &txlogger{
 		config: config,
+		out:    out,
 	}
 	return &tracing.Hooks{
-		OnTxStart: t.OnTxStart,
-		OnTxEnd:   t.OnTxEnd,
-		OnOpcode:  t.OnOpcode,
-		OnFault:   t.OnFault,
+		OnBlockStart: t.OnBlockStart,
+		OnBlockEnd:   t.OnBlockEnd,
+		OnReorg:      t.OnReorg,
+		OnTxStart:    t.OnTxStart,
+		OnTxEnd:      t.OnTxEnd,
+		OnOpcode:     t.OnOpcode,
+		OnFault:      t.OnFault,
 	}, nil
 }
 
@@ -57,7 +143,8 @@ func (t *txlogger) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.
 	if t.tx == nil {
 		return
 	}
-	switch vm.OpCode(op) {
+	opcode := vm.OpCode(op)
+	switch opcode {
 	case vm.SLOAD, vm.BALANCE, vm.EXTCODEHASH, vm.EXTCODESIZE, vm.EXTCODECOPY:
 		t.reads++
 	case vm.SSTORE:
@@ -69,6 +156,24 @@ func (t *txlogger) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.
 	case vm.CREATE, vm.CREATE2:
 		t.creates++
 	}
+
+	if t.config.OpcodeHistogram {
+		if t.opcodeGas == nil {
+			t.opcodeGas = make(map[vm.OpCode]uint64)
+		}
+		t.opcodeGas[opcode] += cost
+	}
+
+	if t.config.TopSlots > 0 && (opcode == vm.SLOAD || opcode == vm.SSTORE) {
+		stack := scope.StackData()
+		if len(stack) > 0 {
+			if t.slotCounts == nil {
+				t.slotCounts = make(map[slotAccess]uint64)
+			}
+			key := slotAccess{addr: scope.Address(), slot: common.Hash(stack[len(stack)-1].Bytes32())}
+			t.slotCounts[key]++
+		}
+	}
 }
 
 func (t *txlogger) OnFault(pc uint64, op byte, gas, cost uint64, _ tracing.OpContext, depth int, err error) {
@@ -85,39 +190,217 @@ func (t *txlogger) OnTxStart(vm *tracing.VMContext, tx *types.Transaction, from
 	t.tx = tx
 	t.from = from
 	t.reads, t.writes, t.calls, t.logs, t.creates, t.faults = 0, 0, 0, 0, 0, 0
+	t.opcodeGas = nil
+	t.slotCounts = nil
 }
 
+// OnTxEnd buffers a txRecord for the just-finished transaction onto the current block, rather
+// than emitting it immediately: the block it belongs to may still be reorged out before it's
+// confirmed, and a prematurely emitted record can't be taken back. See OnBlockStart for when
+// buffered records are actually emitted.
 func (t *txlogger) OnTxEnd(receipt *types.Receipt, err error) {
-	if t.tx == nil {
+	if t.tx == nil || t.current == nil {
+		return
+	}
+	t.current.records = append(t.current.records, &txRecord{
+		tx:         t.tx,
+		from:       t.from,
+		receipt:    receipt,
+		duration:   time.Since(t.start),
+		reads:      t.reads,
+		writes:     t.writes,
+		calls:      t.calls,
+		logs:       t.logs,
+		creates:    t.creates,
+		faults:     t.faults,
+		opcodeGas:  t.opcodeGas,
+		slotCounts: t.slotCounts,
+		execErr:    err,
+	})
+}
+
+// OnBlockStart begins buffering records for a new block.
+func (t *txlogger) OnBlockStart(event tracing.BlockEvent) {
+	t.current = &blockBuffer{
+		number: event.Block.NumberU64(),
+		hash:   event.Block.Hash(),
+	}
+
+	// Flush any already-buffered blocks that are now confirmed, either by the chain's own
+	// notion of SafeBlock, or, if configured, by a fixed confirmation depth. If neither is
+	// available, fall back to defaultConfirmations rather than never flushing anything.
+	confirmations := uint64(t.config.Confirmations)
+	if confirmations == 0 && event.Safe == nil {
+		if !t.warnedNoSafeBlock {
+			log.Warn("txlogger: chain reports no SafeBlock and Confirmations is unset, falling back to a fixed confirmation depth", "confirmations", defaultConfirmations)
+			t.warnedNoSafeBlock = true
+		}
+		confirmations = defaultConfirmations
+	}
+	for len(t.pending) > 0 {
+		b := t.pending[0]
+		var confirmed bool
+		if confirmations > 0 {
+			confirmed = b.number+confirmations <= event.Block.NumberU64()
+		} else {
+			confirmed = b.number <= event.Safe.Number.Uint64()
+		}
+		if !confirmed {
+			break
+		}
+		t.flush(b)
+		t.pending = t.pending[1:]
+	}
+}
+
+// OnBlockEnd moves the block that was just traced onto the pending queue, to be flushed once
+// it's confirmed.
+func (t *txlogger) OnBlockEnd(err error) {
+	if t.current == nil {
 		return
 	}
-	duration := time.Since(t.start)
+	t.pending = append(t.pending, t.current)
+	t.current = nil
+}
+
+// OnReorg is called with the chain of blocks being removed and the chain of blocks replacing
+// them. Blocks in oldChain that were only ever buffered (not yet confirmed) are simply dropped,
+// since nothing was emitted for them. Blocks in oldChain that had already been flushed — a
+// reorg deeper than the configured confirmation depth, which should be rare — get compensating
+// "reverted" records emitted for every transaction they contained.
+func (t *txlogger) OnReorg(oldChain, newChain []*types.Block) {
+	newHashes := make(map[common.Hash]struct{}, len(newChain))
+	for _, b := range newChain {
+		newHashes[b.Hash()] = struct{}{}
+	}
+
+	for _, b := range oldChain {
+		if _, ok := newHashes[b.Hash()]; ok {
+			continue
+		}
+		if removed := t.removePending(b.Hash()); removed != nil {
+			continue
+		}
+		if flushed := t.removeFlushed(b.Hash()); flushed != nil {
+			for _, rec := range flushed.records {
+				t.emit(rec, true)
+			}
+			continue
+		}
+		log.Warn("txlogger: reorged out block predates our flush history, can't emit compensating records", "hash", b.Hash(), "number", b.NumberU64())
+	}
+}
+
+// removePending removes and returns the pending block with the given hash, if any.
+func (t *txlogger) removePending(hash common.Hash) *blockBuffer {
+	for i, b := range t.pending {
+		if b.hash == hash {
+			t.pending = append(t.pending[:i], t.pending[i+1:]...)
+			return b
+		}
+	}
+	return nil
+}
+
+// removeFlushed removes and returns the flushed block with the given hash, if it's still within
+// our flushedHistory window.
+func (t *txlogger) removeFlushed(hash common.Hash) *blockBuffer {
+	for i, b := range t.flushed {
+		if b.hash == hash {
+			t.flushed = append(t.flushed[:i], t.flushed[i+1:]...)
+			return b
+		}
+	}
+	return nil
+}
+
+// flush emits every record buffered for block b, then retains b in the flushed history so a
+// deep reorg can still be compensated for.
+func (t *txlogger) flush(b *blockBuffer) {
+	for _, rec := range b.records {
+		t.emit(rec, false)
+	}
+	t.flushed = append(t.flushed, b)
+	if len(t.flushed) > flushedHistory {
+		t.flushed = t.flushed[len(t.flushed)-flushedHistory:]
+	}
+}
+
+// emit writes out a single buffered record, both as a human-readable log line and as a
+// structured JSON report. reverted marks a compensating record for a tx whose block was
+// reorged out after already being flushed.
+func (t *txlogger) emit(rec *txRecord, reverted bool) {
 	to := ""
-	if t.tx.To() != nil {
-		to = t.tx.To().Hex()
+	if rec.tx.To() != nil {
+		to = rec.tx.To().Hex()
 	}
 
 	// efficiency is defined as gas used per nanosecond
-	efficiency := float64(receipt.GasUsed) / float64(duration.Nanoseconds())
+	efficiency := float64(rec.receipt.GasUsed) / float64(rec.duration.Nanoseconds())
 
 	log.Info(
 		"OnTxEnd",
-		"hash", t.tx.Hash().Hex(),
-		"from", t.from.Hex(),
+		"hash", rec.tx.Hash().Hex(),
+		"from", rec.from.Hex(),
 		"to", to,
-		"value", t.tx.Value().String(),
-		"size", len(t.tx.Data()),
-		"nonce", t.tx.Nonce(),
-		"gas", receipt.GasUsed,
-		"price", t.tx.GasPrice().String(),
-		"duration", duration.Nanoseconds(),
+		"value", rec.tx.Value().String(),
+		"size", len(rec.tx.Data()),
+		"nonce", rec.tx.Nonce(),
+		"gas", rec.receipt.GasUsed,
+		"price", rec.tx.GasPrice().String(),
+		"duration", rec.duration.Nanoseconds(),
 		"efficiency", efficiency,
-		"reads", t.reads,
-		"writes", t.writes,
-		"calls", t.calls,
-		"logs", t.logs,
-		"creates", t.creates,
-		"faults", t.faults,
-		"error", err,
+		"reads", rec.reads,
+		"writes", rec.writes,
+		"calls", rec.calls,
+		"logs", rec.logs,
+		"creates", rec.creates,
+		"faults", rec.faults,
+		"error", rec.execErr,
+		"reverted", reverted,
 	)
+
+	t.writeReport(rec, reverted)
+}
+
+// writeReport emits the structured opcode-histogram / hot-slot report for rec, if either is
+// configured. It is separate from the log.Info call in emit since that is aimed at human
+// operators tailing the node log, while this is aimed at downstream tooling that wants to parse
+// a single JSON document per tx.
+func (t *txlogger) writeReport(rec *txRecord, reverted bool) {
+	if !t.config.OpcodeHistogram && t.config.TopSlots <= 0 {
+		return
+	}
+	report := txReport{TxHash: rec.tx.Hash(), Reverted: reverted}
+
+	if t.config.OpcodeHistogram {
+		report.OpcodeHistogram = make(map[string]uint64, len(rec.opcodeGas))
+		for op, gas := range rec.opcodeGas {
+			report.OpcodeHistogram[op.String()] = gas
+		}
+	}
+
+	if t.config.TopSlots > 0 {
+		slots := make([]hotSlot, 0, len(rec.slotCounts))
+		for k, count := range rec.slotCounts {
+			slots = append(slots, hotSlot{Address: k.addr, Slot: k.slot, Count: count})
+		}
+		sort.Slice(slots, func(i, j int) bool {
+			return slots[i].Count > slots[j].Count
+		})
+		if len(slots) > t.config.TopSlots {
+			slots = slots[:t.config.TopSlots]
+		}
+		report.HotSlots = slots
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Error("txlogger: failed to marshal report", "error", err)
+		return
+	}
+	data = append(data, '\n')
+	if _, err := t.out.Write(data); err != nil {
+		log.Error("txlogger: failed to write report", "error", err)
+	}
 }